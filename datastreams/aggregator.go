@@ -0,0 +1,350 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// statsPoint is generated whenever a checkpoint is set on a pathway, and consumed by the
+// aggregator to build pathway/edge latency stats.
+type statsPoint struct {
+	edgeTags       []string
+	hash           uint64
+	parentHash     uint64
+	timestamp      int64
+	pathwayLatency int64
+	edgeLatency    int64
+
+	// queuePos is the write position this point was pushed at in the aggregator's statsPointQueue
+	// (its slot is queuePos modulo the queue size), used to detect stale reads after the ring
+	// buffer wraps around.
+	queuePos int64
+}
+
+// backlogPoint carries the latest observed value for a tag set, e.g. the committed offset of a
+// (consumer group, topic, partition) or the produced offset of a (topic, partition). The value is
+// shipped as-is; no difference between offsets is computed here. Unlike statsPoints, only the
+// most recently observed value for a given set of tags is reported, on a fixed sampling
+// interval, rather than every point.
+type backlogPoint struct {
+	tags  []string
+	value int64
+}
+
+const backlogSampleInterval = 10 * time.Second
+
+// statsPointPopBackoff is how long the stats processor sleeps between empty polls of the ring
+// buffer, to avoid spinning the CPU while still reacting quickly to new points.
+const statsPointPopBackoff = time.Millisecond
+
+// bucketDuration is the width of a stats aggregation window.
+const bucketDuration = 10 * time.Second
+
+// defaultFlushInterval is how often closed buckets are encoded and shipped to the agent.
+const defaultFlushInterval = 10 * time.Second
+
+// statsGroup accumulates the pathway/edge latency observations for a single (hash, parentHash,
+// edgeTags) group within a bucket.
+type statsGroup struct {
+	hash           uint64
+	parentHash     uint64
+	edgeTags       []string
+	pathwayLatency *logLinearSketch
+	edgeLatency    *logLinearSketch
+}
+
+// statsBucket is a single aggregation window.
+type statsBucket struct {
+	start  time.Time
+	groups map[string]*statsGroup
+}
+
+func newStatsBucket(start time.Time) *statsBucket {
+	return &statsBucket{start: start, groups: make(map[string]*statsGroup)}
+}
+
+func groupKey(hash, parentHash uint64, edgeTags []string) string {
+	sorted := append([]string(nil), edgeTags...)
+	sort.Strings(sorted)
+	return strconv.FormatUint(hash, 10) + ":" + strconv.FormatUint(parentHash, 10) + ":" + strings.Join(sorted, ",")
+}
+
+func bucketStart(timestamp int64) time.Time {
+	t := time.Unix(0, timestamp)
+	return t.Truncate(bucketDuration)
+}
+
+type aggregator struct {
+	in      statsPointQueue
+	backlog chan backlogPoint
+
+	mu      sync.Mutex
+	buckets map[time.Time]*statsBucket
+
+	backlogMu       sync.Mutex
+	latestBacklog   map[string]backlogPoint
+	pendingBacklogs []backlogPoint
+
+	env           string
+	service       string
+	primaryTag    string
+	flushInterval time.Duration
+	transport     *transport
+
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+}
+
+func newAggregator() *aggregator {
+	return &aggregator{
+		backlog:       make(chan backlogPoint, 10000),
+		buckets:       make(map[time.Time]*statsBucket),
+		latestBacklog: make(map[string]backlogPoint),
+		flushInterval: defaultFlushInterval,
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+func (a *aggregator) Start() {
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		a.statsLoop()
+	}()
+	go func() {
+		defer wg.Done()
+		a.backlogLoop()
+	}()
+	go func() {
+		defer wg.Done()
+		a.flushLoop()
+	}()
+	go func() {
+		wg.Wait()
+		a.flushStats(true)
+		close(a.stopped)
+	}()
+}
+
+func (a *aggregator) Stop() {
+	a.stopOnce.Do(func() {
+		close(a.stop)
+	})
+	<-a.stopped
+}
+
+// statsLoop pops statsPoints off the ring buffer in a tight loop, backing off briefly when the
+// buffer is empty, until the aggregator is stopped, at which point it drains what is left.
+func (a *aggregator) statsLoop() {
+	for {
+		select {
+		case <-a.stop:
+			for p := a.in.pop(); p != nil; p = a.in.pop() {
+				a.add(p)
+			}
+			return
+		default:
+		}
+		p := a.in.pop()
+		if p == nil {
+			time.Sleep(statsPointPopBackoff)
+			continue
+		}
+		a.add(p)
+	}
+}
+
+func (a *aggregator) backlogLoop() {
+	ticker := time.NewTicker(backlogSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case p := <-a.backlog:
+			a.recordBacklog(p)
+		case <-ticker.C:
+			a.flushBacklog()
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// flushLoop periodically encodes and ships closed buckets to the agent.
+func (a *aggregator) flushLoop() {
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.flushStats(false)
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+// add folds a statsPoint into its bucket's group stats.
+func (a *aggregator) add(p *statsPoint) {
+	start := bucketStart(p.timestamp)
+	key := groupKey(p.hash, p.parentHash, p.edgeTags)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	bucket, ok := a.buckets[start]
+	if !ok {
+		bucket = newStatsBucket(start)
+		a.buckets[start] = bucket
+	}
+	group, ok := bucket.groups[key]
+	if !ok {
+		group = &statsGroup{
+			hash:           p.hash,
+			parentHash:     p.parentHash,
+			edgeTags:       p.edgeTags,
+			pathwayLatency: newLogLinearSketch(),
+			edgeLatency:    newLogLinearSketch(),
+		}
+		bucket.groups[key] = group
+	}
+	group.pathwayLatency.Add(p.pathwayLatency)
+	group.edgeLatency.Add(p.edgeLatency)
+}
+
+// flushStats encodes and sends every bucket that is done accumulating (i.e. older than the
+// current bucket), or every bucket if all is true, which is used on shutdown. Any backlog points
+// sampled since the last flush are attached to the same payload.
+func (a *aggregator) flushStats(all bool) {
+	if a.transport == nil {
+		return
+	}
+	now := bucketStart(time.Now().UnixNano())
+
+	a.mu.Lock()
+	var ready []*statsBucket
+	for start, bucket := range a.buckets {
+		if all || start.Before(now) {
+			ready = append(ready, bucket)
+			delete(a.buckets, start)
+		}
+	}
+	a.mu.Unlock()
+
+	backlogs := a.takePendingBacklogs()
+
+	if len(ready) == 0 && len(backlogs) == 0 {
+		return
+	}
+	payload := &statsPayload{
+		Env:        a.env,
+		Service:    a.service,
+		PrimaryTag: a.primaryTag,
+		Stats:      make([]statsBucketPayload, 0, len(ready)),
+		Backlogs:   backlogs,
+	}
+	for _, bucket := range ready {
+		payload.Stats = append(payload.Stats, bucket.encode())
+	}
+	if err := a.transport.send(payload); err != nil {
+		log.Println("WARN: failed to send data streams stats payload:", err)
+	}
+}
+
+func (b *statsBucket) encode() statsBucketPayload {
+	out := statsBucketPayload{
+		Start:    uint64(b.start.UnixNano()),
+		Duration: uint64(bucketDuration.Nanoseconds()),
+		Stats:    make([]groupedStatPayload, 0, len(b.groups)),
+	}
+	for _, g := range b.groups {
+		out.Stats = append(out.Stats, groupedStatPayload{
+			Hash:           g.hash,
+			ParentHash:     g.parentHash,
+			EdgeTags:       g.edgeTags,
+			PathwayLatency: g.pathwayLatency.Encode(),
+			EdgeLatency:    g.edgeLatency.Encode(),
+			TimestampType:  "current",
+		})
+	}
+	return out
+}
+
+func backlogKey(tags []string) string {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func (a *aggregator) recordBacklog(p backlogPoint) {
+	a.backlogMu.Lock()
+	defer a.backlogMu.Unlock()
+	a.latestBacklog[backlogKey(p.tags)] = p
+}
+
+// flushBacklog queues the latest observed value for each tracked (group, topic, partition) key,
+// rather than every point received, since backlogs only need periodic sampling. The points are
+// picked up and shipped by the next flushStats call, which shares the same pipeline_stats payload.
+func (a *aggregator) flushBacklog() {
+	a.backlogMu.Lock()
+	defer a.backlogMu.Unlock()
+	for _, p := range a.latestBacklog {
+		a.pendingBacklogs = append(a.pendingBacklogs, p)
+	}
+}
+
+// takePendingBacklogs returns and clears the backlog points queued since the last flushStats call,
+// encoded as the payload shape expected by the agent.
+func (a *aggregator) takePendingBacklogs() []backlogPayload {
+	a.backlogMu.Lock()
+	defer a.backlogMu.Unlock()
+	if len(a.pendingBacklogs) == 0 {
+		return nil
+	}
+	out := make([]backlogPayload, len(a.pendingBacklogs))
+	for i, p := range a.pendingBacklogs {
+		out[i] = backlogPayload{Tags: p.tags, Value: p.value}
+	}
+	a.pendingBacklogs = a.pendingBacklogs[:0]
+	return out
+}
+
+var globalAggregator atomic.Value
+
+func getGlobalAggregator() *aggregator {
+	a, _ := globalAggregator.Load().(*aggregator)
+	return a
+}
+
+func setGlobalAggregator(a *aggregator) {
+	if a == nil {
+		globalAggregator.Store((*aggregator)(nil))
+		return
+	}
+	globalAggregator.Store(a)
+}
+
+// TrackBacklog reports the latest observed value (e.g. an offset) for the given set of tags,
+// such as `type:kafka_commit,consumer_group:g,topic:t,partition:p`. Only the most recent value
+// per tag set is reported on each sampling interval.
+func TrackBacklog(tags []string, value int64) {
+	a := getGlobalAggregator()
+	if a == nil {
+		return
+	}
+	select {
+	case a.backlog <- backlogPoint{tags: tags, value: value}:
+	default:
+	}
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGroupKeyOrdersEdgeTags(t *testing.T) {
+	a := groupKey(1, 2, []string{"topic:a", "direction:in"})
+	b := groupKey(1, 2, []string{"direction:in", "topic:a"})
+	if a != b {
+		t.Fatalf("expected groupKey to be order-independent, got %q and %q", a, b)
+	}
+}
+
+func TestBucketStartTruncatesToBucketDuration(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 3, 0, time.UTC).UnixNano()
+	got := bucketStart(ts)
+	if got.Second() != 0 || !got.Truncate(bucketDuration).Equal(got) {
+		t.Fatalf("expected bucketStart to truncate to a %s boundary, got %s", bucketDuration, got)
+	}
+}
+
+func TestAggregatorAddGroupsByHashParentHashAndEdgeTags(t *testing.T) {
+	a := newAggregator()
+	now := time.Now().UnixNano()
+	a.add(&statsPoint{hash: 1, parentHash: 2, edgeTags: []string{"topic:a"}, timestamp: now, pathwayLatency: 10, edgeLatency: 5})
+	a.add(&statsPoint{hash: 1, parentHash: 2, edgeTags: []string{"topic:a"}, timestamp: now, pathwayLatency: 20, edgeLatency: 7})
+	a.add(&statsPoint{hash: 3, parentHash: 4, edgeTags: []string{"topic:b"}, timestamp: now, pathwayLatency: 1, edgeLatency: 1})
+
+	start := bucketStart(now)
+	bucket, ok := a.buckets[start]
+	if !ok {
+		t.Fatal("expected a bucket for the current window")
+	}
+	if len(bucket.groups) != 2 {
+		t.Fatalf("expected 2 distinct groups, got %d", len(bucket.groups))
+	}
+	group := bucket.groups[groupKey(1, 2, []string{"topic:a"})]
+	if group == nil {
+		t.Fatal("expected to find the group for hash 1")
+	}
+	var total uint64
+	for _, count := range group.pathwayLatency.counts {
+		total += count
+	}
+	if total != 2 {
+		t.Fatalf("expected 2 observations folded into the group's pathway sketch, got %d", total)
+	}
+}
+
+func TestAggregatorFlushStatsSendsStatsAndBacklogs(t *testing.T) {
+	received := make(chan *statsPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		received <- &statsPayload{}
+	}))
+	defer srv.Close()
+
+	a := newAggregator()
+	a.transport = newTransport(srv.URL)
+	a.env = "test"
+
+	now := time.Now().UnixNano()
+	a.add(&statsPoint{hash: 1, parentHash: 2, edgeTags: []string{"topic:a"}, timestamp: now})
+	a.recordBacklog(backlogPoint{tags: []string{"topic:a", "partition:0"}, value: 42})
+	a.flushBacklog()
+
+	a.flushStats(true)
+
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("expected flushStats to send a payload to the transport")
+	}
+	if len(a.buckets) != 0 {
+		t.Fatal("expected flushStats(true) to drain all buckets")
+	}
+	if len(a.pendingBacklogs) != 0 {
+		t.Fatal("expected flushStats to consume pending backlogs")
+	}
+}
+
+func TestAggregatorFlushBacklogKeepsOnlyLatestPerKey(t *testing.T) {
+	a := newAggregator()
+	a.recordBacklog(backlogPoint{tags: []string{"topic:a"}, value: 1})
+	a.recordBacklog(backlogPoint{tags: []string{"topic:a"}, value: 2})
+	a.flushBacklog()
+
+	backlogs := a.takePendingBacklogs()
+	if len(backlogs) != 1 {
+		t.Fatalf("expected a single backlog entry per tag set, got %d", len(backlogs))
+	}
+	if backlogs[0].Value != 2 {
+		t.Fatalf("expected the latest recorded value 2, got %d", backlogs[0].Value)
+	}
+}
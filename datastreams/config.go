@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// service is the name reported on every node of every pathway created by this process.
+var service atomic.Value
+
+func getService() string {
+	if s, ok := service.Load().(string); ok {
+		return s
+	}
+	return ""
+}
+
+func setService(s string) {
+	service.Store(s)
+}
+
+const defaultAgentURL = "http://localhost:8126"
+
+// Config configures the global aggregator installed by Start.
+type Config struct {
+	// AgentURL is the base URL of the Datadog agent. Defaults to http://localhost:8126. A
+	// unix:// URL (e.g. "unix:///var/run/datadog/apm.socket") ships stats over a trace-agent
+	// unix domain socket instead of HTTP.
+	AgentURL string
+	// APIKey, if set, is sent on the DD-API-Key header of every request. Only needed when
+	// AgentURL points directly at Datadog's intake rather than at a local agent.
+	APIKey string
+	// Env is the env tag reported alongside every bucket.
+	Env string
+	// Service is the name of this service, reported on every pathway node and on every bucket.
+	Service string
+	// PrimaryTag is an optional extra tag (e.g. an availability zone) reported on every bucket.
+	PrimaryTag string
+	// FlushInterval is how often buckets are flushed to the agent. Defaults to 10 seconds.
+	FlushInterval time.Duration
+}
+
+// Start installs the global aggregator used by SetCheckpoint and TrackBacklog, and starts
+// periodically flushing aggregated pathway stats to the Datadog agent. Call Stop to flush any
+// remaining stats and tear it down.
+func Start(cfg Config) {
+	if cfg.AgentURL == "" {
+		cfg.AgentURL = defaultAgentURL
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	setService(cfg.Service)
+	a := newAggregator()
+	a.env = cfg.Env
+	a.service = cfg.Service
+	a.primaryTag = cfg.PrimaryTag
+	a.flushInterval = cfg.FlushInterval
+	a.transport = newAuthenticatedTransport(cfg.AgentURL, cfg.APIKey)
+	a.Start()
+	setGlobalAggregator(a)
+}
+
+// Stop flushes any remaining stats and uninstalls the global aggregator.
+func Stop() {
+	a := getGlobalAggregator()
+	if a == nil {
+		return
+	}
+	setGlobalAggregator(nil)
+	a.Stop()
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeStableRegardlessOfOrder(t *testing.T) {
+	now := time.Now()
+	a := newPathway(now).setCheckpoint(now, []string{"type:kafka", "topic:a"})
+	b := newPathway(now).setCheckpoint(now, []string{"type:kafka", "topic:b"})
+	c := newPathway(now).setCheckpoint(now, []string{"type:kafka", "topic:c"})
+
+	merged1 := merge([]Pathway{a, b, c}, now)
+	merged2 := merge([]Pathway{c, a, b}, now)
+	merged3 := merge([]Pathway{b, c, a}, now)
+
+	if merged1.hash != merged2.hash || merged1.hash != merged3.hash {
+		t.Fatalf("expected merge to be order-independent, got hashes %d, %d, %d",
+			merged1.hash, merged2.hash, merged3.hash)
+	}
+}
+
+func TestMergeContinuedChainTraceableFromInputs(t *testing.T) {
+	now := time.Now()
+	a := newPathway(now).setCheckpoint(now, []string{"topic:a"})
+	b := newPathway(now).setCheckpoint(now, []string{"topic:b"})
+
+	merged := merge([]Pathway{a, b}, now)
+	child := merged.setCheckpoint(now.Add(time.Second), []string{"type:kafka", "direction:in"})
+
+	wantParentHash := combineHashes([]uint64{a.hash, b.hash})
+	if merged.hash != pathwayHash(nodeHash(merged.service, merged.edgeTags), wantParentHash) {
+		t.Fatalf("merged pathway hash does not derive from the combined parent hash")
+	}
+	if child.hash == merged.hash {
+		t.Fatalf("expected continuing the merged pathway to produce a new hash")
+	}
+}
+
+func TestMergeSingleInputIsPassthrough(t *testing.T) {
+	now := time.Now()
+	p := newPathway(now)
+	if got := merge([]Pathway{p}, now); got.hash != p.hash {
+		t.Fatal("expected merging a single pathway to return it unchanged")
+	}
+}
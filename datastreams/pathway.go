@@ -8,7 +8,6 @@ package datastreams
 import (
 	"encoding/binary"
 	"hash/fnv"
-	"log"
 	"math/rand"
 	"sort"
 	"time"
@@ -35,18 +34,92 @@ type Pathway struct {
 	edgeTags []string
 }
 
-// Merge merges multiple pathways into one.
-// The current implementation samples one resulting Pathway. A future implementation could be more clever
-// and actually merge the Pathways.
+// Merge merges multiple pathways into one, preserving fan-in topology: the returned Pathway's
+// parent hash is a deterministic combination of every input's hash, so the backend can render a
+// many-to-one edge rather than picking a single upstream pathway at random.
 func Merge(pathways []Pathway) Pathway {
+	return merge(pathways, time.Now())
+}
+
+func merge(pathways []Pathway, now time.Time) Pathway {
+	if len(pathways) == 0 {
+		return Pathway{}
+	}
+	if len(pathways) == 1 {
+		return pathways[0]
+	}
+
+	hashes := make([]uint64, len(pathways))
+	earliestPathwayStart := pathways[0].pathwayStart
+	earliestEdgeStart := pathways[0].edgeStart
+	seenTags := make(map[string]struct{})
+	var edgeTags []string
+	for i, p := range pathways {
+		hashes[i] = p.hash
+		if p.pathwayStart.Before(earliestPathwayStart) {
+			earliestPathwayStart = p.pathwayStart
+		}
+		if p.edgeStart.Before(earliestEdgeStart) {
+			earliestEdgeStart = p.edgeStart
+		}
+		for _, t := range p.edgeTags {
+			if _, ok := seenTags[t]; ok {
+				continue
+			}
+			seenTags[t] = struct{}{}
+			edgeTags = append(edgeTags, t)
+		}
+	}
+	sort.Strings(edgeTags)
+
+	parentHash := combineHashes(hashes)
+	service := getService()
+	child := Pathway{
+		hash:         pathwayHash(nodeHash(service, edgeTags), parentHash),
+		pathwayStart: earliestPathwayStart,
+		edgeStart:    now,
+		service:      service,
+		edgeTags:     edgeTags,
+	}
+	if aggregator := getGlobalAggregator(); aggregator != nil {
+		aggregator.in.push(&statsPoint{
+			edgeTags:       edgeTags,
+			parentHash:     parentHash,
+			hash:           child.hash,
+			timestamp:      now.UnixNano(),
+			pathwayLatency: now.Sub(earliestPathwayStart).Nanoseconds(),
+			edgeLatency:    now.Sub(earliestEdgeStart).Nanoseconds(),
+		})
+	}
+	return child
+}
+
+// MergeSampled merges multiple pathways by randomly selecting one of them to propagate
+// downstream, exactly as Merge used to behave. Prefer Merge, which preserves fan-in topology;
+// this is kept for callers that want the cheaper, lossy behavior.
+func MergeSampled(pathways []Pathway) Pathway {
 	if len(pathways) == 0 {
 		return Pathway{}
 	}
-	// Randomly select a pathway to propagate downstream.
 	n := rand.Intn(len(pathways))
 	return pathways[n]
 }
 
+// combineHashes folds a set of pathway hashes into a single deterministic hash, independent of
+// their input order, so that Merge produces a stable parent hash regardless of which order the
+// fanned-in pathways are observed in.
+func combineHashes(hashes []uint64) uint64 {
+	sorted := append([]uint64(nil), hashes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	b := make([]byte, 8*len(sorted))
+	for i, h := range sorted {
+		binary.LittleEndian.PutUint64(b[i*8:], h)
+	}
+	hasher := fnv.New64()
+	hasher.Write(b)
+	return hasher.Sum64()
+}
+
 func nodeHash(service string, edgeTags []string) uint64 {
 	n := len(service)
 	sort.Strings(edgeTags)
@@ -101,18 +174,14 @@ func (p Pathway) setCheckpoint(now time.Time, edgeTags []string) Pathway {
 		edgeTags:     edgeTags,
 	}
 	if aggregator := getGlobalAggregator(); aggregator != nil {
-		select {
-		case aggregator.in <- statsPoint{
+		aggregator.in.push(&statsPoint{
 			edgeTags:       edgeTags,
 			parentHash:     p.hash,
 			hash:           child.hash,
 			timestamp:      now.UnixNano(),
 			pathwayLatency: now.Sub(p.pathwayStart).Nanoseconds(),
 			edgeLatency:    now.Sub(p.edgeStart).Nanoseconds(),
-		}:
-		default:
-			log.Println("WARN: Aggregator input channel full, disregarding stats point.")
-		}
+		})
 	}
 	return child
-}
\ No newline at end of file
+}
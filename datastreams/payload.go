@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"io"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// statsPayload is the top level payload sent to the agent's /v0.1/pipeline_stats endpoint.
+type statsPayload struct {
+	Env        string
+	Service    string
+	PrimaryTag string
+	Stats      []statsBucketPayload
+	Backlogs   []backlogPayload
+}
+
+// backlogPayload reports the latest observed value for a single tracked (group, topic, partition)
+// tag set, e.g. a commit or produce offset.
+type backlogPayload struct {
+	Tags  []string
+	Value int64
+}
+
+// statsBucketPayload is a single 10s aggregation window.
+type statsBucketPayload struct {
+	Start    uint64
+	Duration uint64
+	Stats    []groupedStatPayload
+}
+
+// groupedStatPayload is the aggregated latency stats for one (hash, parentHash, edgeTags) group
+// within a bucket.
+type groupedStatPayload struct {
+	Hash           uint64
+	ParentHash     uint64
+	EdgeTags       []string
+	PathwayLatency []byte
+	EdgeLatency    []byte
+	TimestampType  string
+}
+
+// EncodeMsgp writes the payload to w in the MessagePack encoding expected by the agent.
+func (p *statsPayload) EncodeMsgp(w io.Writer) error {
+	mw := msgp.NewWriter(w)
+	if err := mw.WriteMapHeader(5); err != nil {
+		return err
+	}
+	for _, kv := range []struct {
+		key   string
+		value string
+	}{
+		{"Env", p.Env},
+		{"Service", p.Service},
+		{"PrimaryTag", p.PrimaryTag},
+	} {
+		if err := mw.WriteString(kv.key); err != nil {
+			return err
+		}
+		if err := mw.WriteString(kv.value); err != nil {
+			return err
+		}
+	}
+	if err := mw.WriteString("Stats"); err != nil {
+		return err
+	}
+	if err := mw.WriteArrayHeader(uint32(len(p.Stats))); err != nil {
+		return err
+	}
+	for _, bucket := range p.Stats {
+		if err := bucket.encodeMsgp(mw); err != nil {
+			return err
+		}
+	}
+	if err := mw.WriteString("Backlogs"); err != nil {
+		return err
+	}
+	if err := mw.WriteArrayHeader(uint32(len(p.Backlogs))); err != nil {
+		return err
+	}
+	for _, b := range p.Backlogs {
+		if err := b.encodeMsgp(mw); err != nil {
+			return err
+		}
+	}
+	return mw.Flush()
+}
+
+func (b *backlogPayload) encodeMsgp(mw *msgp.Writer) error {
+	if err := mw.WriteMapHeader(2); err != nil {
+		return err
+	}
+	if err := mw.WriteString("Tags"); err != nil {
+		return err
+	}
+	if err := mw.WriteArrayHeader(uint32(len(b.Tags))); err != nil {
+		return err
+	}
+	for _, tag := range b.Tags {
+		if err := mw.WriteString(tag); err != nil {
+			return err
+		}
+	}
+	if err := mw.WriteString("Value"); err != nil {
+		return err
+	}
+	return mw.WriteInt64(b.Value)
+}
+
+func (b *statsBucketPayload) encodeMsgp(mw *msgp.Writer) error {
+	if err := mw.WriteMapHeader(3); err != nil {
+		return err
+	}
+	if err := mw.WriteString("Start"); err != nil {
+		return err
+	}
+	if err := mw.WriteUint64(b.Start); err != nil {
+		return err
+	}
+	if err := mw.WriteString("Duration"); err != nil {
+		return err
+	}
+	if err := mw.WriteUint64(b.Duration); err != nil {
+		return err
+	}
+	if err := mw.WriteString("Stats"); err != nil {
+		return err
+	}
+	if err := mw.WriteArrayHeader(uint32(len(b.Stats))); err != nil {
+		return err
+	}
+	for _, s := range b.Stats {
+		if err := s.encodeMsgp(mw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *groupedStatPayload) encodeMsgp(mw *msgp.Writer) error {
+	if err := mw.WriteMapHeader(6); err != nil {
+		return err
+	}
+	if err := mw.WriteString("Hash"); err != nil {
+		return err
+	}
+	if err := mw.WriteUint64(s.Hash); err != nil {
+		return err
+	}
+	if err := mw.WriteString("ParentHash"); err != nil {
+		return err
+	}
+	if err := mw.WriteUint64(s.ParentHash); err != nil {
+		return err
+	}
+	if err := mw.WriteString("EdgeTags"); err != nil {
+		return err
+	}
+	if err := mw.WriteArrayHeader(uint32(len(s.EdgeTags))); err != nil {
+		return err
+	}
+	for _, tag := range s.EdgeTags {
+		if err := mw.WriteString(tag); err != nil {
+			return err
+		}
+	}
+	if err := mw.WriteString("PathwayLatency"); err != nil {
+		return err
+	}
+	if err := mw.WriteBytes(s.PathwayLatency); err != nil {
+		return err
+	}
+	if err := mw.WriteString("EdgeLatency"); err != nil {
+		return err
+	}
+	if err := mw.WriteBytes(s.EdgeLatency); err != nil {
+		return err
+	}
+	if err := mw.WriteString("TimestampType"); err != nil {
+		return err
+	}
+	return mw.WriteString(s.TimestampType)
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStatsPayloadEncodeMsgp(t *testing.T) {
+	payload := &statsPayload{
+		Env:        "test",
+		Service:    "svc",
+		PrimaryTag: "region:us",
+		Stats: []statsBucketPayload{
+			{
+				Start:    1,
+				Duration: uint64(bucketDuration.Nanoseconds()),
+				Stats: []groupedStatPayload{
+					{
+						Hash:           1,
+						ParentHash:     2,
+						EdgeTags:       []string{"topic:a"},
+						PathwayLatency: newLogLinearSketch().Encode(),
+						EdgeLatency:    newLogLinearSketch().Encode(),
+						TimestampType:  "current",
+					},
+				},
+			},
+		},
+		Backlogs: []backlogPayload{
+			{Tags: []string{"topic:a", "partition:0"}, Value: 42},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := payload.EncodeMsgp(&buf); err != nil {
+		t.Fatalf("unexpected error encoding payload: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("expected a non-empty encoded payload")
+	}
+}
+
+func TestStatsPayloadEncodeMsgpEmpty(t *testing.T) {
+	payload := &statsPayload{}
+	var buf bytes.Buffer
+	if err := payload.EncodeMsgp(&buf); err != nil {
+		t.Fatalf("unexpected error encoding an empty payload: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("expected the map/array headers to still be written for an empty payload")
+	}
+}
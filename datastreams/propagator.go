@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// PropagationKey is the key to use to propagate the pathway between services.
+const PropagationKey = "dd-pathway-ctx"
+
+type pathwayCtxKey struct{}
+
+// ContextWithPathway returns a copy of the given context which includes the pathway p.
+func ContextWithPathway(ctx context.Context, p Pathway) context.Context {
+	return context.WithValue(ctx, pathwayCtxKey{}, p)
+}
+
+// PathwayFromContext extracts the pathway from a context, if any is found.
+func PathwayFromContext(ctx context.Context) (Pathway, bool) {
+	p, ok := ctx.Value(pathwayCtxKey{}).(Pathway)
+	return p, ok
+}
+
+// SetCheckpoint sets a checkpoint on the pathway found in ctx, creating a new pathway if none is found,
+// and returns the resulting pathway along with a context carrying it.
+func SetCheckpoint(ctx context.Context, edgeTags ...string) (Pathway, context.Context) {
+	p, ok := PathwayFromContext(ctx)
+	if !ok {
+		p = NewPathway()
+	}
+	p = p.SetCheckpoint(edgeTags...)
+	return p, ContextWithPathway(ctx, p)
+}
+
+// Encode encodes a pathway into a binary representation, so that it can be propagated alongside a payload.
+func (p Pathway) Encode() []byte {
+	b := make([]byte, 8, 20)
+	binary.LittleEndian.PutUint64(b, p.hash)
+	b = binary.AppendVarint(b, p.pathwayStart.UnixMilli())
+	b = binary.AppendVarint(b, p.edgeStart.UnixMilli())
+	return b
+}
+
+// Decode decodes a pathway from the binary representation produced by Pathway.Encode.
+func Decode(data []byte) (p Pathway, err error) {
+	if len(data) < 8 {
+		return p, errors.New("data_streams: payload too small to contain a pathway")
+	}
+	p.hash = binary.LittleEndian.Uint64(data)
+	pathwayStart, n := binary.Varint(data[8:])
+	if n <= 0 {
+		return p, errors.New("data_streams: can't decode pathway start")
+	}
+	edgeStart, n2 := binary.Varint(data[8+n:])
+	if n2 <= 0 {
+		return p, errors.New("data_streams: can't decode edge start")
+	}
+	p.pathwayStart = msToTime(pathwayStart)
+	p.edgeStart = msToTime(edgeStart)
+	// The wire format carries no service name: a decoded pathway is, by definition, re-entering
+	// a process at a boundary, so stamp the local service the same way NewPathway does for a
+	// pathway originating in this process.
+	p.service = getService()
+	return p, nil
+}
+
+func msToTime(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+// InjectBinary encodes a pathway for propagation through a message header.
+// It is the shared entry point used by the messaging integrations (Kafka and friends)
+// so that the wire format stays identical regardless of which client library is in use.
+func InjectBinary(p Pathway) []byte {
+	return p.Encode()
+}
+
+// ExtractBinary decodes a pathway previously encoded with InjectBinary.
+func ExtractBinary(data []byte) (Pathway, error) {
+	return Decode(data)
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import "testing"
+
+func TestDecodeStampsLocalService(t *testing.T) {
+	defer setService(getService())
+
+	setService("service-a")
+	wire := NewPathway().SetCheckpoint("topic:a").Encode()
+
+	setService("service-b")
+	b, err := Decode(wire)
+	if err != nil {
+		t.Fatalf("unexpected error decoding pathway: %v", err)
+	}
+	if b.service != "service-b" {
+		t.Fatalf("expected the decoded pathway to carry the local service %q, got %q", "service-b", b.service)
+	}
+}
+
+func TestDecodeDifferentServicesProduceDistinctHashes(t *testing.T) {
+	defer setService(getService())
+
+	setService("service-a")
+	wire := NewPathway().SetCheckpoint("topic:a").Encode()
+
+	setService("service-b")
+	b, err := Decode(wire)
+	if err != nil {
+		t.Fatalf("unexpected error decoding pathway: %v", err)
+	}
+	bChecked := b.SetCheckpoint("direction:in")
+
+	setService("service-d")
+	d, err := Decode(wire)
+	if err != nil {
+		t.Fatalf("unexpected error decoding pathway: %v", err)
+	}
+	dChecked := d.SetCheckpoint("direction:in")
+
+	if bChecked.hash == dChecked.hash {
+		t.Fatal("expected two different consuming services to produce distinct pathway hashes")
+	}
+}
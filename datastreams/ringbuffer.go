@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import "sync/atomic"
+
+// statsPointQueueSize is the number of in-flight statsPoints the ring buffer can hold before it
+// starts overwriting entries that haven't been read yet.
+const statsPointQueueSize = 10000
+
+// statsPointQueue is a lock-free, many-producers/single-consumer bounded ring buffer of
+// *statsPoint. It replaces the previous channel-based queue, which suffered from mutex
+// contention on setCheckpoint's hot path under load.
+//
+// Writers never block: push always succeeds, silently overwriting the oldest entry that hasn't
+// been read yet once the buffer wraps around. The single consumer goroutine is expected to call
+// pop in a tight loop, backing off briefly when the queue is empty.
+type statsPointQueue struct {
+	elements [statsPointQueueSize]atomic.Pointer[statsPoint]
+	writePos int64
+	readPos  int64
+}
+
+// push enqueues p, overwriting the oldest unread entry if the buffer is full.
+func (q *statsPointQueue) push(p *statsPoint) {
+	ind := atomic.AddInt64(&q.writePos, 1)
+	p.queuePos = ind - 1
+	q.elements[(ind-1)%statsPointQueueSize].Store(p)
+}
+
+// pop dequeues the oldest unread entry, or returns nil if the queue is empty or the writer that
+// reserved the next slot hasn't stored into it yet.
+func (q *statsPointQueue) pop() *statsPoint {
+	writePos := atomic.LoadInt64(&q.writePos)
+	readPos := atomic.LoadInt64(&q.readPos)
+	if readPos >= writePos {
+		return nil
+	}
+	if oldest := writePos - statsPointQueueSize; readPos < oldest {
+		// Writers have lapped the reader since it last advanced, overwriting every entry
+		// before oldest; skip forward instead of replaying slots whose data is already gone.
+		readPos = oldest
+	}
+	elem := q.elements[readPos%statsPointQueueSize].Load()
+	if elem == nil || elem.queuePos < readPos {
+		// The writer that owns this slot has reserved it but hasn't stored into it yet, so
+		// what we just read (if anything) is stale data from a previous lap.
+		return nil
+	}
+	atomic.StoreInt64(&q.readPos, elem.queuePos+1)
+	return elem
+}
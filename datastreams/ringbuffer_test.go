@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestStatsPointQueue(t *testing.T) {
+	q := &statsPointQueue{}
+	for i := 0; i < 5; i++ {
+		q.push(&statsPoint{hash: uint64(i)})
+	}
+	for i := 0; i < 5; i++ {
+		p := q.pop()
+		if p == nil {
+			t.Fatalf("expected a point at index %d, got nil", i)
+		}
+		if p.hash != uint64(i) {
+			t.Fatalf("expected hash %d, got %d", i, p.hash)
+		}
+	}
+	if p := q.pop(); p != nil {
+		t.Fatalf("expected nil once queue is drained, got %+v", p)
+	}
+}
+
+func TestStatsPointQueueOverflow(t *testing.T) {
+	// Overflowing the queue must never block a writer or panic a reader: the oldest
+	// not-yet-read entries are silently overwritten, and pop must never replay a slot it has
+	// already returned.
+	q := &statsPointQueue{}
+	const overflow = 10
+	for i := 0; i < statsPointQueueSize+overflow; i++ {
+		q.push(&statsPoint{hash: uint64(i)})
+	}
+	seen := make(map[uint64]bool)
+	var order []uint64
+	for p := q.pop(); p != nil; p = q.pop() {
+		if seen[p.hash] {
+			t.Fatalf("hash %d popped more than once", p.hash)
+		}
+		seen[p.hash] = true
+		order = append(order, p.hash)
+	}
+	if want := statsPointQueueSize; len(seen) != want {
+		t.Fatalf("expected exactly %d surviving entries, got %d", want, len(seen))
+	}
+	for i, hash := range order {
+		want := uint64(overflow + i)
+		if hash != want {
+			t.Fatalf("expected entries to drain oldest-to-newest starting at %d: index %d was %d, want %d", overflow, i, hash, want)
+		}
+	}
+}
+
+func BenchmarkStatsPointQueue(b *testing.B) {
+	q := &statsPointQueue{}
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for p := 0; p < 8; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < b.N; i++ {
+				q.push(&statsPoint{hash: uint64(i)})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkStatsPointChannel(b *testing.B) {
+	ch := make(chan statsPoint, statsPointQueueSize)
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+			case <-done:
+				return
+			}
+		}
+	}()
+	b.ResetTimer()
+	for p := 0; p < 8; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < b.N; i++ {
+				select {
+				case ch <- statsPoint{hash: uint64(i)}:
+				default:
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// logLinearSketch is a compact log-linear histogram used to summarize pathway/edge latencies
+// within a stats bucket. Values (nanoseconds) are bucketed by their base-1.1 log, giving ~10%
+// relative accuracy with a fixed, small number of buckets, which is cheap enough to keep one per
+// (hash, parentHash, edgeTags) group per 10s bucket.
+type logLinearSketch struct {
+	counts map[int32]uint64
+}
+
+const logLinearBase = 1.1
+
+func newLogLinearSketch() *logLinearSketch {
+	return &logLinearSketch{counts: make(map[int32]uint64)}
+}
+
+// Add records a single observation, in nanoseconds.
+func (s *logLinearSketch) Add(v int64) {
+	if v < 0 {
+		v = 0
+	}
+	idx := int32(0)
+	if v > 0 {
+		idx = int32(math.Log(float64(v)) / math.Log(logLinearBase))
+	}
+	s.counts[idx]++
+}
+
+// Encode serializes the sketch as a sequence of (bucket index, count) varint pairs, sorted by
+// bucket index, so it can be embedded in the stats payload sent to the agent.
+func (s *logLinearSketch) Encode() []byte {
+	indexes := make([]int32, 0, len(s.counts))
+	for idx := range s.counts {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	b := make([]byte, 0, len(indexes)*10)
+	for _, idx := range indexes {
+		b = appendVarint(b, int64(idx))
+		b = appendUvarint(b, s.counts[idx])
+	}
+	return b
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(buf, v)
+	return append(b, buf[:n]...)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, v)
+	return append(b, buf[:n]...)
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestLogLinearSketchAddNegativeClampsToZero(t *testing.T) {
+	s := newLogLinearSketch()
+	s.Add(-5)
+	s.Add(0)
+	if got := s.counts[0]; got != 2 {
+		t.Fatalf("expected both observations in bucket 0, got count %d", got)
+	}
+}
+
+func TestLogLinearSketchEncodeSortedByBucket(t *testing.T) {
+	s := newLogLinearSketch()
+	s.Add(1_000_000)
+	s.Add(1_000_000)
+	s.Add(100)
+
+	b := s.Encode()
+
+	var lastIdx int64
+	first := true
+	for len(b) > 0 {
+		idx, n := binary.Varint(b)
+		if n <= 0 {
+			t.Fatalf("failed to decode bucket index varint")
+		}
+		b = b[n:]
+		count, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatalf("failed to decode count varint")
+		}
+		b = b[n:]
+		if !first && idx <= lastIdx {
+			t.Fatalf("expected buckets in increasing order, got %d after %d", idx, lastIdx)
+		}
+		lastIdx, first = idx, false
+		if count == 0 {
+			t.Fatalf("encoded a bucket with a zero count")
+		}
+	}
+}
+
+func TestLogLinearSketchEncodeEmpty(t *testing.T) {
+	s := newLogLinearSketch()
+	if b := s.Encode(); len(b) != 0 {
+		t.Fatalf("expected an empty sketch to encode to no bytes, got %d", len(b))
+	}
+}
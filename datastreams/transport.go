@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const pipelineStatsEndpoint = "/v0.1/pipeline_stats"
+
+// unixSocketPrefix marks an agentURL as a trace-agent unix domain socket path rather than an
+// HTTP(S) base URL, e.g. "unix:///var/run/datadog/apm.socket".
+const unixSocketPrefix = "unix://"
+
+// transport ships encoded stats payloads to the Datadog agent.
+type transport struct {
+	agentURL string
+	apiKey   string
+	client   *http.Client
+}
+
+func newTransport(agentURL string) *transport {
+	return newAuthenticatedTransport(agentURL, "")
+}
+
+// newAuthenticatedTransport builds a transport that POSTs to agentURL, which may either be an
+// http(s):// base URL or a unix:// socket path for talking to a trace-agent listening on a UDS.
+// When apiKey is non-empty, it is sent on the DD-API-Key header, as required when shipping stats
+// directly to Datadog's intake rather than through a local agent.
+func newAuthenticatedTransport(agentURL, apiKey string) *transport {
+	client := &http.Client{Timeout: 10 * time.Second}
+	if socketPath, ok := strings.CutPrefix(agentURL, unixSocketPrefix); ok {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		// The request URL still needs a valid host for the http package to route through our
+		// DialContext, which ignores it and always dials the unix socket above.
+		agentURL = "http://unix"
+	}
+	return &transport{
+		agentURL: agentURL,
+		apiKey:   apiKey,
+		client:   client,
+	}
+}
+
+// send gzip-compresses and POSTs the payload to the agent's pipeline stats endpoint.
+func (t *transport) send(payload *statsPayload) error {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := payload.EncodeMsgp(gz); err != nil {
+		return fmt.Errorf("data_streams: encoding payload: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("data_streams: compressing payload: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, t.agentURL+pipelineStatsEndpoint, &buf)
+	if err != nil {
+		return fmt.Errorf("data_streams: building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set("Content-Encoding", "gzip")
+	if t.apiKey != "" {
+		req.Header.Set("DD-API-Key", t.apiKey)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("data_streams: sending payload: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("data_streams: agent returned status %d", resp.StatusCode)
+	}
+	return nil
+}
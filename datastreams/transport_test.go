@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package datastreams
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestTransportSendSetsAPIKeyHeader(t *testing.T) {
+	var gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("DD-API-Key")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tr := newAuthenticatedTransport(srv.URL, "test-api-key")
+	if err := tr.send(&statsPayload{}); err != nil {
+		t.Fatalf("unexpected error sending payload: %v", err)
+	}
+	if gotKey != "test-api-key" {
+		t.Fatalf("expected DD-API-Key header %q, got %q", "test-api-key", gotKey)
+	}
+}
+
+func TestTransportSendOverUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "apm.socket")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("unexpected error listening on unix socket: %v", err)
+	}
+	defer ln.Close()
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != pipelineStatsEndpoint {
+			t.Errorf("expected request to %s, got %s", pipelineStatsEndpoint, r.URL.Path)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	tr := newTransport(unixSocketPrefix + socketPath)
+	if err := tr.send(&statsPayload{}); err != nil {
+		t.Fatalf("unexpected error sending payload over unix socket: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package kafkatrace holds helpers shared by the Kafka client integrations
+// (confluent-kafka-go, Shopify/sarama, segmentio/kafka-go), which each represent message
+// headers with a different concrete type.
+package kafkatrace
+
+import (
+	"context"
+
+	"github.com/DataDog/data-streams-go/datastreams"
+)
+
+// ExtractPathway walks n message headers, accessed through header, looking for the Data Streams
+// Monitoring propagation header. If found, it returns ctx with the extracted pathway attached;
+// otherwise it returns ctx unchanged.
+func ExtractPathway(ctx context.Context, n int, header func(i int) (key string, value []byte)) context.Context {
+	for i := 0; i < n; i++ {
+		key, value := header(i)
+		if key != datastreams.PropagationKey {
+			continue
+		}
+		p, err := datastreams.ExtractBinary(value)
+		if err != nil {
+			return ctx
+		}
+		return datastreams.ContextWithPathway(ctx, p)
+	}
+	return ctx
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/confluentinc/confluent-kafka-go/kafka"
 
 	"github.com/DataDog/data-streams-go/datastreams"
+	"github.com/DataDog/data-streams-go/integrations/internal/kafkatrace"
 )
 
 // TraceKafkaConsume extracts the pathway from to the kafka message header to the context.
@@ -29,14 +30,8 @@ func TraceKafkaConsume(ctx context.Context, msg *kafka.Message, group string) co
 }
 
 func extractPipelineToContext(ctx context.Context, m *kafka.Message) context.Context {
-	for _, header := range m.Headers {
-		if header.Key == datastreams.PropagationKey {
-			p, err := datastreams.Decode(header.Value)
-			if err != nil {
-				return ctx
-			}
-			return datastreams.ContextWithPathway(ctx, p)
-		}
-	}
-	return ctx
+	return kafkatrace.ExtractPathway(ctx, len(m.Headers), func(i int) (string, []byte) {
+		h := m.Headers[i]
+		return h.Key, h.Value
+	})
 }
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"strconv"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+
+	"github.com/DataDog/data-streams-go/datastreams"
+)
+
+// TrackKafkaCommitOffset reports the latest committed offset for each partition in offsets. The
+// aggregator ships the latest value per partition as-is; it does not compute the backlog itself.
+func TrackKafkaCommitOffset(group string, offsets []kafka.TopicPartition) {
+	for _, tp := range offsets {
+		if tp.Topic == nil {
+			continue
+		}
+		tags := []string{
+			"type:kafka_commit",
+			"consumer_group:" + group,
+			"topic:" + *tp.Topic,
+			"partition:" + strconv.Itoa(int(tp.Partition)),
+		}
+		datastreams.TrackBacklog(tags, int64(tp.Offset))
+	}
+}
+
+// TrackKafkaProduceOffset reports the latest produced offset for a topic partition. The
+// aggregator ships the latest value per partition as-is; it does not compute the lag itself.
+func TrackKafkaProduceOffset(topic string, partition int32, offset int64) {
+	tags := []string{
+		"type:kafka_produce",
+		"topic:" + topic,
+		"partition:" + strconv.Itoa(int(partition)),
+	}
+	datastreams.TrackBacklog(tags, offset)
+}
@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"context"
+
+	"github.com/confluentinc/confluent-kafka-go/kafka"
+
+	"github.com/DataDog/data-streams-go/datastreams"
+)
+
+// TraceKafkaProduce sets a checkpoint on the pathway found in ctx (creating one if none is
+// found), injects the resulting pathway into msg's headers, and returns the updated context.
+func TraceKafkaProduce(ctx context.Context, msg *kafka.Message) context.Context {
+	edges := []string{"type:kafka", "direction:out"}
+	if msg.TopicPartition.Topic != nil {
+		edges = append(edges, "topic:"+*msg.TopicPartition.Topic)
+	}
+	pathway, ctx := datastreams.SetCheckpoint(ctx, edges...)
+	msg.Headers = append(msg.Headers, kafka.Header{
+		Key:   datastreams.PropagationKey,
+		Value: datastreams.InjectBinary(pathway),
+	})
+	return ctx
+}
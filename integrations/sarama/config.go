@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sarama
+
+type config struct {
+	dataStreamsEnabled bool
+	groupID            string
+}
+
+// Option customizes the behavior of the sarama wrappers.
+type Option func(*config)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithDataStreams enables Data Streams Monitoring checkpoints on the wrapped consumer/producer.
+func WithDataStreams() Option {
+	return func(cfg *config) {
+		cfg.dataStreamsEnabled = true
+	}
+}
+
+// WithGroupID tags checkpoints emitted by a wrapped consumer with the given consumer group.
+func WithGroupID(groupID string) Option {
+	return func(cfg *config) {
+		cfg.groupID = groupID
+	}
+}
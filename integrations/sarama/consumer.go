@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sarama
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/DataDog/data-streams-go/datastreams"
+	"github.com/DataDog/data-streams-go/integrations/internal/kafkatrace"
+)
+
+// Consumer wraps a sarama.Consumer, setting Data Streams Monitoring checkpoints on every
+// consumed message.
+type Consumer struct {
+	sarama.Consumer
+	cfg *config
+}
+
+// WrapConsumer wraps a sarama.Consumer so that partition consumers created through it report
+// Data Streams Monitoring checkpoints.
+func WrapConsumer(c sarama.Consumer, opts ...Option) *Consumer {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &Consumer{Consumer: c, cfg: cfg}
+}
+
+// ConsumePartition invokes the wrapped Consumer's ConsumePartition and wraps the resulting
+// PartitionConsumer so its messages carry Data Streams Monitoring checkpoints.
+func (c *Consumer) ConsumePartition(topic string, partition int32, offset int64) (*PartitionConsumer, error) {
+	pc, err := c.Consumer.ConsumePartition(topic, partition, offset)
+	if err != nil {
+		return nil, err
+	}
+	return wrapPartitionConsumer(pc, c.cfg), nil
+}
+
+// ConsumerMessage pairs a consumed sarama.ConsumerMessage with the context carrying the pathway
+// extracted from (or created for) it, so callers can continue the pathway into a downstream
+// produce call.
+type ConsumerMessage struct {
+	*sarama.ConsumerMessage
+	Context context.Context
+}
+
+// PartitionConsumer wraps a sarama.PartitionConsumer, setting Data Streams Monitoring checkpoints
+// on every message read from Messages().
+type PartitionConsumer struct {
+	sarama.PartitionConsumer
+	messages chan *ConsumerMessage
+}
+
+// WrapPartitionConsumer wraps a sarama.PartitionConsumer so every message read from Messages()
+// carries a Data Streams Monitoring checkpoint.
+func WrapPartitionConsumer(pc sarama.PartitionConsumer, opts ...Option) *PartitionConsumer {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return wrapPartitionConsumer(pc, cfg)
+}
+
+func wrapPartitionConsumer(pc sarama.PartitionConsumer, cfg *config) *PartitionConsumer {
+	wrapped := &PartitionConsumer{
+		PartitionConsumer: pc,
+		messages:          make(chan *ConsumerMessage),
+	}
+	go wrapped.run(cfg)
+	return wrapped
+}
+
+func (pc *PartitionConsumer) run(cfg *config) {
+	defer close(pc.messages)
+	for msg := range pc.PartitionConsumer.Messages() {
+		ctx := context.Background()
+		if cfg.dataStreamsEnabled {
+			ctx = traceConsume(msg, cfg.groupID)
+		}
+		pc.messages <- &ConsumerMessage{ConsumerMessage: msg, Context: ctx}
+	}
+}
+
+// Messages returns the channel of consumed messages, each carrying the context produced by its
+// Data Streams Monitoring checkpoint so it can be threaded into a downstream TraceKafkaProduce to
+// continue the pathway. The sarama producer wrappers take no context: they only continue a
+// pathway found in the produced message's headers.
+func (pc *PartitionConsumer) Messages() <-chan *ConsumerMessage {
+	return pc.messages
+}
+
+func traceConsume(msg *sarama.ConsumerMessage, group string) context.Context {
+	ctx := extractPipelineToContext(context.Background(), msg)
+	edges := []string{"type:kafka", "direction:in", "group:" + group, "topic:" + msg.Topic,
+		"partition:" + strconv.Itoa(int(msg.Partition))}
+	_, ctx = datastreams.SetCheckpoint(ctx, edges...)
+	return ctx
+}
+
+func extractPipelineToContext(ctx context.Context, msg *sarama.ConsumerMessage) context.Context {
+	return kafkatrace.ExtractPathway(ctx, len(msg.Headers), func(i int) (string, []byte) {
+		h := msg.Headers[i]
+		if h == nil {
+			return "", nil
+		}
+		return string(h.Key), h.Value
+	})
+}
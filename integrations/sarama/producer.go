@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sarama
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Shopify/sarama"
+
+	"github.com/DataDog/data-streams-go/datastreams"
+	"github.com/DataDog/data-streams-go/integrations/internal/kafkatrace"
+)
+
+// SyncProducer wraps a sarama.SyncProducer, setting a Data Streams Monitoring checkpoint on
+// every message before it is sent.
+type SyncProducer struct {
+	sarama.SyncProducer
+	cfg *config
+}
+
+// WrapSyncProducer wraps a sarama.SyncProducer so every produced message carries a Data Streams
+// Monitoring checkpoint.
+func WrapSyncProducer(p sarama.SyncProducer, opts ...Option) *SyncProducer {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &SyncProducer{SyncProducer: p, cfg: cfg}
+}
+
+// SendMessage sets a Data Streams Monitoring checkpoint on msg, then delegates to the wrapped
+// SyncProducer.
+func (p *SyncProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	if p.cfg.dataStreamsEnabled {
+		traceProduce(msg)
+	}
+	return p.SyncProducer.SendMessage(msg)
+}
+
+// AsyncProducer wraps a sarama.AsyncProducer, setting a Data Streams Monitoring checkpoint on
+// every message sent through Input().
+type AsyncProducer struct {
+	sarama.AsyncProducer
+	input     chan *sarama.ProducerMessage
+	done      chan struct{}
+	closeOnce sync.Once
+	cfg       *config
+}
+
+// WrapAsyncProducer wraps a sarama.AsyncProducer so every produced message carries a Data
+// Streams Monitoring checkpoint.
+func WrapAsyncProducer(p sarama.AsyncProducer, opts ...Option) *AsyncProducer {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	wrapped := &AsyncProducer{
+		AsyncProducer: p,
+		input:         make(chan *sarama.ProducerMessage),
+		done:          make(chan struct{}),
+		cfg:           cfg,
+	}
+	go wrapped.run()
+	return wrapped
+}
+
+func (p *AsyncProducer) run() {
+	defer close(p.done)
+	for msg := range p.input {
+		if p.cfg.dataStreamsEnabled {
+			traceProduce(msg)
+		}
+		p.AsyncProducer.Input() <- msg
+	}
+}
+
+// Input returns the channel used to produce messages, each of which is checkpointed before
+// being forwarded to the wrapped AsyncProducer.
+func (p *AsyncProducer) Input() chan<- *sarama.ProducerMessage {
+	return p.input
+}
+
+// closeInput closes the wrapper's input channel and waits for run() to drain and exit, so that
+// it never forwards onto the embedded AsyncProducer's Input() concurrently with it being torn
+// down by Close/AsyncClose.
+func (p *AsyncProducer) closeInput() {
+	p.closeOnce.Do(func() {
+		close(p.input)
+	})
+	<-p.done
+}
+
+// Close shuts down the wrapper's forwarding goroutine before delegating to the embedded
+// AsyncProducer's Close.
+func (p *AsyncProducer) Close() error {
+	p.closeInput()
+	return p.AsyncProducer.Close()
+}
+
+// AsyncClose shuts down the wrapper's forwarding goroutine before delegating to the embedded
+// AsyncProducer's AsyncClose.
+func (p *AsyncProducer) AsyncClose() {
+	p.closeInput()
+	p.AsyncProducer.AsyncClose()
+}
+
+// traceProduce extracts any pathway already present on msg.Headers before checkpointing, so that
+// relaying a message produced through a sarama consumer wrapper (or any other propagator)
+// continues its pathway instead of starting a disconnected one.
+func traceProduce(msg *sarama.ProducerMessage) {
+	ctx := kafkatrace.ExtractPathway(context.Background(), len(msg.Headers), func(i int) (string, []byte) {
+		h := msg.Headers[i]
+		return string(h.Key), h.Value
+	})
+	edges := []string{"type:kafka", "direction:out", "topic:" + msg.Topic}
+	pathway, _ := datastreams.SetCheckpoint(ctx, edges...)
+	msg.Headers = append(msg.Headers, sarama.RecordHeader{
+		Key:   []byte(datastreams.PropagationKey),
+		Value: datastreams.InjectBinary(pathway),
+	})
+}
@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package segmentio provides Data Streams Monitoring instrumentation for
+// github.com/segmentio/kafka-go Readers and Writers.
+package segmentio
+
+type config struct {
+	groupID string
+}
+
+// Option customizes the behavior of the Reader/Writer wrappers.
+type Option func(*config)
+
+func defaultConfig() *config {
+	return &config{}
+}
+
+// WithGroupID tags checkpoints emitted by a wrapped Reader with the given consumer group.
+func WithGroupID(groupID string) Option {
+	return func(cfg *config) {
+		cfg.groupID = groupID
+	}
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package segmentio
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/DataDog/data-streams-go/datastreams"
+	"github.com/DataDog/data-streams-go/integrations/internal/kafkatrace"
+)
+
+// Reader wraps a kafka.Reader, setting a Data Streams Monitoring checkpoint on every message
+// it fetches.
+type Reader struct {
+	*kafka.Reader
+	cfg *config
+}
+
+// NewReader creates a Reader which wraps a new kafka.Reader built from readerConfig.
+func NewReader(readerConfig kafka.ReaderConfig, opts ...Option) *Reader {
+	return WrapReader(kafka.NewReader(readerConfig), opts...)
+}
+
+// WrapReader wraps an existing kafka.Reader so that every message it fetches carries a Data
+// Streams Monitoring checkpoint.
+func WrapReader(r *kafka.Reader, opts ...Option) *Reader {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.groupID == "" {
+		cfg.groupID = r.Config().GroupID
+	}
+	return &Reader{Reader: r, cfg: cfg}
+}
+
+// FetchMessage reads and returns the next message from the reader, along with the context
+// carrying the pathway checkpointed for it, so the pathway can be continued into a downstream
+// produce call.
+func (r *Reader) FetchMessage(ctx context.Context) (kafka.Message, context.Context, error) {
+	msg, err := r.Reader.FetchMessage(ctx)
+	if err != nil {
+		return msg, ctx, err
+	}
+	return msg, r.traceConsume(msg), nil
+}
+
+// ReadMessage reads and returns the next message from the reader, along with the context
+// carrying the pathway checkpointed for it, so the pathway can be continued into a downstream
+// produce call.
+func (r *Reader) ReadMessage(ctx context.Context) (kafka.Message, context.Context, error) {
+	msg, err := r.Reader.ReadMessage(ctx)
+	if err != nil {
+		return msg, ctx, err
+	}
+	return msg, r.traceConsume(msg), nil
+}
+
+func (r *Reader) traceConsume(msg kafka.Message) context.Context {
+	ctx := extractPipelineToContext(context.Background(), msg)
+	edges := []string{"type:kafka", "direction:in", "group:" + r.cfg.groupID, "topic:" + msg.Topic,
+		"partition:" + strconv.Itoa(msg.Partition)}
+	_, ctx = datastreams.SetCheckpoint(ctx, edges...)
+	return ctx
+}
+
+func extractPipelineToContext(ctx context.Context, msg kafka.Message) context.Context {
+	return kafkatrace.ExtractPathway(ctx, len(msg.Headers), func(i int) (string, []byte) {
+		h := msg.Headers[i]
+		return h.Key, h.Value
+	})
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package segmentio
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/DataDog/data-streams-go/datastreams"
+)
+
+// Writer wraps a kafka.Writer, setting a Data Streams Monitoring checkpoint on every message
+// before it is written.
+type Writer struct {
+	*kafka.Writer
+	cfg *config
+}
+
+// NewWriter wraps w so that every message written through it carries a Data Streams Monitoring
+// checkpoint. w may be configured in either single-topic mode (w.Topic set) or multi-topic mode
+// (the topic is read from each kafka.Message instead).
+func NewWriter(w *kafka.Writer, opts ...Option) *Writer {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &Writer{Writer: w, cfg: cfg}
+}
+
+// WriteMessages sets a Data Streams Monitoring checkpoint on each message, then delegates to the
+// wrapped Writer.
+func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for i := range msgs {
+		w.traceProduce(ctx, &msgs[i])
+	}
+	return w.Writer.WriteMessages(ctx, msgs...)
+}
+
+// traceProduce sets a checkpoint on the pathway found in ctx (continuing it, if for instance ctx
+// is the context returned from a prior Reader.FetchMessage/ReadMessage call), rather than always
+// starting a new one.
+func (w *Writer) traceProduce(ctx context.Context, msg *kafka.Message) {
+	topic := w.Writer.Topic
+	if topic == "" {
+		topic = msg.Topic
+	}
+	edges := []string{"type:kafka", "direction:out", "topic:" + topic}
+	pathway, _ := datastreams.SetCheckpoint(ctx, edges...)
+	msg.Headers = append(msg.Headers, kafka.Header{
+		Key:   datastreams.PropagationKey,
+		Value: datastreams.InjectBinary(pathway),
+	})
+}